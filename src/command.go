@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"lit/src/objects"
+)
+
+// Command is one lit subcommand. Run receives the arguments following the
+// subcommand name (i.e. os.Args[2:]) and reports failure by returning an
+// error rather than calling os.Exit, so commands stay callable without
+// tearing down the process - only main decides how to report a failure.
+// The one exception is flag parsing: each Run parses its flags with
+// flag.ExitOnError, so a malformed flag (or -h) still exits the process.
+// Flags mirrors the flag.FlagSet Run parses internally; it exists purely so
+// `lit help <command>` can print usage for its flags and is never itself
+// parsed against real arguments.
+type Command struct {
+	Name  string
+	Short string
+	Flags *flag.FlagSet
+	Run   func(args []string) error
+}
+
+var commands = []Command{
+	{Name: "init", Short: "create an empty Git repository", Flags: helpFlags(func(m flag.ErrorHandling) *flag.FlagSet { fs, _ := initFlags(m); return fs }), Run: runInit},
+	{Name: "cat-file", Short: "print the contents of a Git object", Flags: helpFlags(func(m flag.ErrorHandling) *flag.FlagSet { fs, _ := catFileFlags(m); return fs }), Run: runCatFile},
+	{Name: "hash-object", Short: "compute (and optionally store) the object ID for a file", Flags: helpFlags(func(m flag.ErrorHandling) *flag.FlagSet { fs, _ := hashObjectFlags(m); return fs }), Run: runHashObject},
+	{Name: "ls-tree", Short: "list the contents of a tree object", Flags: helpFlags(func(m flag.ErrorHandling) *flag.FlagSet { fs, _ := lsTreeFlags(m); return fs }), Run: runLsTree},
+	{Name: "write-tree", Short: "write the working directory as a tree object", Flags: nil, Run: runWriteTree},
+	{Name: "commit-tree", Short: "create a commit object from a tree and parent", Flags: helpFlags(func(m flag.ErrorHandling) *flag.FlagSet { fs, _, _ := commitTreeFlags(m); return fs }), Run: runCommitTree},
+	{Name: "commit", Short: "record the working directory as a new commit", Flags: helpFlags(func(m flag.ErrorHandling) *flag.FlagSet { fs, _ := commitFlags(m); return fs }), Run: runCommit},
+	{Name: "log", Short: "show commit history starting from HEAD", Flags: nil, Run: runLog},
+	{Name: "unpack-objects", Short: "unpack a pack file into loose objects", Flags: nil, Run: runUnpackObjects},
+	{Name: "pack-objects", Short: "pack objects read from stdin into a pack file", Flags: nil, Run: runPackObjects},
+	{Name: "verify-tree", Short: "hash a directory independent of the object store", Flags: helpFlags(func(m flag.ErrorHandling) *flag.FlagSet { fs, _, _ := verifyTreeFlags(m); return fs }), Run: runVerifyTree},
+}
+
+// helpFlags builds a FlagSet for display purposes only, by calling the same
+// constructor the command's Run uses to parse real arguments. This keeps
+// `lit help <command>` from drifting out of sync with what Run actually
+// accepts, since both read from one definition.
+func helpFlags(newFlags func(flag.ErrorHandling) *flag.FlagSet) *flag.FlagSet {
+	fs := newFlags(flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	return fs
+}
+
+func lookupCommand(name string) *Command {
+	for i := range commands {
+		if commands[i].Name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// runHelp implements `lit help` and `lit help <command>`.
+func runHelp(args []string) error {
+	if len(args) == 0 {
+		fmt.Println("usage: lit <command> [<args>...]")
+		fmt.Println()
+		fmt.Println("commands:")
+		for _, cmd := range commands {
+			fmt.Printf("  %-16s %s\n", cmd.Name, cmd.Short)
+		}
+		return nil
+	}
+
+	cmd := lookupCommand(args[0])
+	if cmd == nil {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	fmt.Printf("usage: lit %s - %s\n", cmd.Name, cmd.Short)
+	if cmd.Flags != nil {
+		cmd.Flags.PrintDefaults()
+	}
+	return nil
+}
+
+// openObject reads and inflates the object named by sha, returning its type
+// tag and whole body. Commands that need the full content (rather than a
+// stream) go through this shared helper instead of re-deriving the object
+// path and zlib plumbing themselves.
+func openObject(sha string) (objType string, body []byte, err error) {
+	typ, rc, err := objects.OpenObject(sha)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rc.Close()
+
+	body, err = io.ReadAll(rc)
+	if err != nil {
+		return "", nil, fmt.Errorf("reading object %s: %w", sha, err)
+	}
+	return string(typ), body, nil
+}
+
+// writeObject hashes payload under the usual "<type> <size>\0" header using
+// the repo's configured hash format, optionally persisting it as a loose
+// object under .git/objects/xx/yyy..., and returns its hex object ID either
+// way.
+func writeObject(objType string, payload []byte, write bool) (string, error) {
+	header := fmt.Sprintf("%s %d\x00", objType, len(payload))
+	content := append([]byte(header), payload...)
+
+	hasher, err := objects.CurrentHasher()
+	if err != nil {
+		return "", err
+	}
+	h := hasher.New()
+	h.Write(content)
+	sha := fmt.Sprintf("%x", h.Sum(nil))
+
+	if !write {
+		return sha, nil
+	}
+
+	dir := fmt.Sprintf(".git/objects/%s", sha[:2])
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating object directory: %w", err)
+	}
+
+	path := fmt.Sprintf("%s/%s", dir, sha[2:])
+	if _, err := os.Stat(path); err == nil {
+		return sha, nil
+	}
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(content); err != nil {
+		zw.Close()
+		return "", fmt.Errorf("compressing object: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("compressing object: %w", err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("writing object: %w", err)
+	}
+	return sha, nil
+}
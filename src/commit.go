@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// authorTimezone is the fixed offset lit stamps on every author/committer
+// trailer. Real git derives this from the local clock; we keep it constant
+// so commits are reproducible regardless of where lit runs.
+const authorTimezone = "+0000"
+
+// commitTreeFlags builds the "commit-tree" FlagSet, shared between
+// runCommitTree and the Command registry's help text.
+func commitTreeFlags(mode flag.ErrorHandling) (fs *flag.FlagSet, parent, message *string) {
+	fs = flag.NewFlagSet("commit-tree", mode)
+	parent = fs.String("p", "", "parent commit SHA")
+	message = fs.String("m", "", "commit message")
+	return fs, parent, message
+}
+
+func runCommitTree(args []string) error {
+	// <tree_sha> may appear before, between, or after the flags, so split
+	// flag tokens from positional ones ourselves: flag.Parse stops consuming
+	// flags at the first non-flag argument, which would otherwise silently
+	// drop -p/-m whenever the tree SHA comes first.
+	flagArgs, positional := splitPositional(args, map[string]bool{"p": true, "m": true})
+
+	fs, parent, message := commitTreeFlags(flag.ExitOnError)
+	fs.Parse(flagArgs)
+
+	if len(positional) < 1 {
+		return fmt.Errorf("usage: lit commit-tree <tree_sha> [-p <parent_sha>] -m <message>")
+	}
+
+	sha, err := commitTree(positional[0], *parent, *message)
+	if err != nil {
+		return err
+	}
+	fmt.Println(sha)
+	return nil
+}
+
+// splitPositional separates args into flag tokens and positional tokens so
+// flags can be parsed regardless of where the positional argument falls.
+// flagsWithArg lists flag names (without leading "-") that consume the
+// following token as their value.
+func splitPositional(args []string, flagsWithArg map[string]bool) (flagArgs, positional []string) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if !strings.HasPrefix(a, "-") {
+			positional = append(positional, a)
+			continue
+		}
+		flagArgs = append(flagArgs, a)
+		name := strings.TrimLeft(a, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		if flagsWithArg[name] && i+1 < len(args) {
+			i++
+			flagArgs = append(flagArgs, args[i])
+		}
+	}
+	return flagArgs, positional
+}
+
+func commitTree(treeSha, parent, message string) (string, error) {
+	identity, err := readIdentity()
+	if err != nil {
+		return "", err
+	}
+	stamp := fmt.Sprintf("%d %s", time.Now().Unix(), authorTimezone)
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "tree %s\n", treeSha)
+	if parent != "" {
+		fmt.Fprintf(&body, "parent %s\n", parent)
+	}
+	fmt.Fprintf(&body, "author %s %s\n", identity, stamp)
+	fmt.Fprintf(&body, "committer %s %s\n", identity, stamp)
+	fmt.Fprintf(&body, "\n%s\n", message)
+
+	return writeObject("commit", body.Bytes(), true)
+}
+
+// readIdentity resolves the "Name <email>" string used for author/committer
+// trailers: GIT_AUTHOR_NAME/GIT_AUTHOR_EMAIL take priority, then
+// user.name/user.email from .git/config, then a fixed default.
+func readIdentity() (string, error) {
+	name := os.Getenv("GIT_AUTHOR_NAME")
+	email := os.Getenv("GIT_AUTHOR_EMAIL")
+
+	if name == "" || email == "" {
+		cfgName, cfgEmail, err := readUserConfig(".git/config")
+		if err != nil {
+			return "", err
+		}
+		if name == "" {
+			name = cfgName
+		}
+		if email == "" {
+			email = cfgEmail
+		}
+	}
+
+	if name == "" {
+		name = "lit"
+	}
+	if email == "" {
+		email = "lit@example.com"
+	}
+	return fmt.Sprintf("%s <%s>", name, email), nil
+}
+
+// readUserConfig extracts user.name and user.email from a [user] section of
+// a git-style config file. Returns empty strings (no error) if the file or
+// section is absent.
+func readUserConfig(path string) (name, email string, err error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("reading %s: %w", path, err)
+	}
+	defer f.Close()
+
+	inUserSection := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "["):
+			inUserSection = line == "[user]"
+		case inUserSection && strings.HasPrefix(line, "name"):
+			_, v, ok := strings.Cut(line, "=")
+			if ok {
+				name = strings.TrimSpace(v)
+			}
+		case inUserSection && strings.HasPrefix(line, "email"):
+			_, v, ok := strings.Cut(line, "=")
+			if ok {
+				email = strings.TrimSpace(v)
+			}
+		}
+	}
+	return name, email, scanner.Err()
+}
+
+// currentBranch resolves the branch name HEAD points at, e.g. "main".
+func currentBranch() (string, error) {
+	content, err := os.ReadFile(".git/HEAD")
+	if err != nil {
+		return "", fmt.Errorf("reading HEAD: %w", err)
+	}
+	ref := strings.TrimSpace(string(content))
+	const prefix = "ref: refs/heads/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("lit: detached HEAD is not supported")
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+// resolveHead returns the commit SHA the current branch points at, or ""
+// if the branch has no commits yet.
+func resolveHead() (string, error) {
+	branch, err := currentBranch()
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(fmt.Sprintf(".git/refs/heads/%s", branch))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading ref: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func updateHead(sha string) error {
+	branch, err := currentBranch()
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf(".git/refs/heads/%s", branch)
+	if err := os.MkdirAll(".git/refs/heads", 0755); err != nil {
+		return fmt.Errorf("creating refs/heads: %w", err)
+	}
+	return os.WriteFile(path, []byte(sha+"\n"), 0644)
+}
+
+// commitFlags builds the "commit" FlagSet, shared between runCommit and the
+// Command registry's help text.
+func commitFlags(mode flag.ErrorHandling) (fs *flag.FlagSet, message *string) {
+	fs = flag.NewFlagSet("commit", mode)
+	message = fs.String("m", "", "commit message")
+	return fs, message
+}
+
+func runCommit(args []string) error {
+	fs, message := commitFlags(flag.ExitOnError)
+	fs.Parse(args)
+
+	if *message == "" {
+		return fmt.Errorf("usage: lit commit -m <message>")
+	}
+
+	treeSha, err := writeTree(".")
+	if err != nil {
+		return err
+	}
+
+	parent, err := resolveHead()
+	if err != nil {
+		return err
+	}
+
+	sha, err := commitTree(treeSha, parent, *message)
+	if err != nil {
+		return err
+	}
+
+	if err := updateHead(sha); err != nil {
+		return err
+	}
+
+	fmt.Println(sha)
+	return nil
+}
+
+type parsedCommit struct {
+	tree    string
+	parent  string
+	author  string
+	message string
+}
+
+func parseCommit(body []byte) parsedCommit {
+	var c parsedCommit
+	headers, message, _ := bytes.Cut(body, []byte("\n\n"))
+	c.message = strings.TrimRight(string(message), "\n")
+
+	for _, line := range strings.Split(string(headers), "\n") {
+		switch {
+		case strings.HasPrefix(line, "tree "):
+			c.tree = strings.TrimPrefix(line, "tree ")
+		case strings.HasPrefix(line, "parent "):
+			c.parent = strings.TrimPrefix(line, "parent ")
+		case strings.HasPrefix(line, "author "):
+			c.author = strings.TrimPrefix(line, "author ")
+		}
+	}
+	return c
+}
+
+func runLog(args []string) error {
+	fs := flag.NewFlagSet("log", flag.ExitOnError)
+	fs.Parse(args)
+
+	sha := fs.Arg(0)
+	if sha == "" {
+		head, err := resolveHead()
+		if err != nil {
+			return err
+		}
+		sha = head
+	}
+
+	for sha != "" {
+		typeName, body, err := openObject(sha)
+		if err != nil {
+			return err
+		}
+		if typeName != "commit" {
+			return fmt.Errorf("%s is not a commit", sha)
+		}
+
+		c := parseCommit(body)
+		fmt.Printf("commit %s\n", sha)
+		fmt.Printf("Author: %s\n", c.author)
+		fmt.Printf("\n    %s\n\n", strings.ReplaceAll(c.message, "\n", "\n    "))
+
+		sha = c.parent
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestSplitPositionalFlagsAfterPositional(t *testing.T) {
+	flagsWithArg := map[string]bool{"p": true, "m": true}
+
+	flagArgs, positional := splitPositional([]string{"deadbeef", "-m", "hello world"}, flagsWithArg)
+
+	if len(positional) != 1 || positional[0] != "deadbeef" {
+		t.Fatalf("positional = %v, want [deadbeef]", positional)
+	}
+	want := []string{"-m", "hello world"}
+	if len(flagArgs) != len(want) {
+		t.Fatalf("flagArgs = %v, want %v", flagArgs, want)
+	}
+	for i := range want {
+		if flagArgs[i] != want[i] {
+			t.Fatalf("flagArgs = %v, want %v", flagArgs, want)
+		}
+	}
+}
+
+func TestSplitPositionalFlagsInterspersed(t *testing.T) {
+	flagsWithArg := map[string]bool{"p": true, "m": true}
+
+	flagArgs, positional := splitPositional(
+		[]string{"-p", "parentsha", "deadbeef", "-m", "hello"}, flagsWithArg)
+
+	if len(positional) != 1 || positional[0] != "deadbeef" {
+		t.Fatalf("positional = %v, want [deadbeef]", positional)
+	}
+	want := []string{"-p", "parentsha", "-m", "hello"}
+	if len(flagArgs) != len(want) {
+		t.Fatalf("flagArgs = %v, want %v", flagArgs, want)
+	}
+	for i := range want {
+		if flagArgs[i] != want[i] {
+			t.Fatalf("flagArgs = %v, want %v", flagArgs, want)
+		}
+	}
+}
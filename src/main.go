@@ -5,26 +5,33 @@ import (
 	"os"
 )
 
-// Usage: your_program.sh <command> <arg1> <arg2> ...
+// Usage: lit <command> [<args>...]
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "usage: mygit <command> [<args>...]\n")
+		fmt.Fprintln(os.Stderr, "usage: lit <command> [<args>...]")
 		os.Exit(1)
 	}
 
-	switch command := os.Args[1]; command {
-	case "init":
-		InitRepository()
-	case "cat-file":
-		CatFile()
-	case "hash-object":
-		HashObject()
-	case "ls-tree":
-		LsTree()
-	case "write-tree":
-		WriteTree()
-	default:
-		fmt.Fprintf(os.Stderr, "Unknown command %s\n", command)
+	command := os.Args[1]
+	args := os.Args[2:]
+
+	if command == "help" {
+		if err := runHelp(args); err != nil {
+			fmt.Fprintf(os.Stderr, "lit: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cmd := lookupCommand(command)
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "lit: unknown command %q\n", command)
+		fmt.Fprintln(os.Stderr, "run 'lit help' for a list of commands")
+		os.Exit(1)
+	}
+
+	if err := cmd.Run(args); err != nil {
+		fmt.Fprintf(os.Stderr, "lit %s: %v\n", cmd.Name, err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,88 @@
+package objects
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+)
+
+// Hasher abstracts the object-ID hash algorithm a repository uses, so the
+// object store, tree parser, and path routing can support both the legacy
+// SHA-1 format and the SHA-256 format tracked by extensions.objectFormat.
+type Hasher interface {
+	Size() int
+	New() hash.Hash
+}
+
+type sha1Hasher struct{}
+
+func (sha1Hasher) Size() int      { return sha1.Size }
+func (sha1Hasher) New() hash.Hash { return sha1.New() }
+
+type sha256Hasher struct{}
+
+func (sha256Hasher) Size() int      { return sha256.Size }
+func (sha256Hasher) New() hash.Hash { return sha256.New() }
+
+// SHA1 and SHA256 are the two object formats lit understands.
+var (
+	SHA1   Hasher = sha1Hasher{}
+	SHA256 Hasher = sha256Hasher{}
+)
+
+// HasherFor resolves the Hasher for a `[extensions] objectFormat` value,
+// treating "" the same as the default "sha1".
+func HasherFor(format string) (Hasher, error) {
+	switch format {
+	case "", "sha1":
+		return SHA1, nil
+	case "sha256":
+		return SHA256, nil
+	default:
+		return nil, fmt.Errorf("objects: unknown object format %q", format)
+	}
+}
+
+// CurrentHasher reads extensions.objectFormat out of .git/config, defaulting
+// to SHA-1 for repositories that predate the extension or have no config
+// file at all.
+func CurrentHasher() (Hasher, error) {
+	format, err := readObjectFormat(".git/config")
+	if err != nil {
+		return nil, err
+	}
+	return HasherFor(format)
+}
+
+func readObjectFormat(path string) (string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "sha1", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+	defer f.Close()
+
+	inExtensions := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "["):
+			inExtensions = line == "[extensions]"
+		case inExtensions && strings.HasPrefix(line, "objectFormat"):
+			if _, v, ok := strings.Cut(line, "="); ok {
+				return strings.TrimSpace(v), nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "sha1", nil
+}
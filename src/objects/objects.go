@@ -0,0 +1,357 @@
+// Package objects provides streaming access to the loose objects under
+// .git/objects: opening and type-sniffing them, parsing tree entries, and
+// exposing any tree SHA as a read-only io/fs.FS so callers can walk or read
+// historical snapshots without shelling out to lit itself.
+package objects
+
+import (
+	"bufio"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Type is a Git object type tag: "blob", "tree", "commit", or "tag".
+type Type string
+
+const (
+	TypeBlob   Type = "blob"
+	TypeTree   Type = "tree"
+	TypeCommit Type = "commit"
+	TypeTag    Type = "tag"
+)
+
+// TreeEntry is one line of a tree object: a mode, a name, and the hex object
+// ID (SHA-1 or SHA-256, per the repo's object format) of the blob or tree it
+// points to.
+type TreeEntry struct {
+	Mode string
+	Name string
+	Sha  string
+}
+
+// bodyReadCloser streams an object's body through the zlib decompressor
+// while closing both the zlib reader and the backing file together.
+type bodyReadCloser struct {
+	io.Reader
+	zr   io.Closer
+	file io.Closer
+}
+
+func (b *bodyReadCloser) Close() error {
+	zErr := b.zr.Close()
+	fErr := b.file.Close()
+	if zErr != nil {
+		return zErr
+	}
+	return fErr
+}
+
+// OpenObject opens the loose object named by sha, inflates it, and returns
+// its type along with a reader positioned just past the "<type> <size>\0"
+// header, ready to stream the body.
+func OpenObject(sha string) (Type, io.ReadCloser, error) {
+	if len(sha) < 3 {
+		return "", nil, fmt.Errorf("objects: invalid sha %q", sha)
+	}
+	path := filepath.Join(".git", "objects", sha[:2], sha[2:])
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("opening object %s: %w", sha, err)
+	}
+
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		f.Close()
+		return "", nil, fmt.Errorf("decompressing object %s: %w", sha, err)
+	}
+
+	br := bufio.NewReader(zr)
+	header, err := br.ReadString(0)
+	if err != nil {
+		zr.Close()
+		f.Close()
+		return "", nil, fmt.Errorf("reading object %s header: %w", sha, err)
+	}
+	typeName, _, ok := strings.Cut(strings.TrimSuffix(header, "\x00"), " ")
+	if !ok {
+		zr.Close()
+		f.Close()
+		return "", nil, fmt.Errorf("objects: malformed header %q for %s", header, sha)
+	}
+
+	return Type(typeName), &bodyReadCloser{Reader: br, zr: zr, file: f}, nil
+}
+
+// ParseTree streams the entries of a tree object body, reading the mode and
+// name up to their delimiters and short-reading the raw object ID (20 bytes
+// for SHA-1, 32 for SHA-256, per the repo's object format) with io.ReadFull
+// rather than buffering the whole object up front.
+func ParseTree(r io.Reader) ([]TreeEntry, error) {
+	hasher, err := CurrentHasher()
+	if err != nil {
+		return nil, err
+	}
+	shaSize := hasher.Size()
+
+	br := bufio.NewReader(r)
+
+	var entries []TreeEntry
+	for {
+		if _, err := br.Peek(1); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("objects: reading tree: %w", err)
+		}
+
+		header, err := br.ReadString(0)
+		if err != nil {
+			return nil, fmt.Errorf("objects: reading tree entry header: %w", err)
+		}
+		mode, name, ok := strings.Cut(strings.TrimSuffix(header, "\x00"), " ")
+		if !ok {
+			return nil, fmt.Errorf("objects: malformed tree entry %q", header)
+		}
+
+		sha := make([]byte, shaSize)
+		if _, err := io.ReadFull(br, sha); err != nil {
+			return nil, fmt.Errorf("objects: reading tree entry sha: %w", err)
+		}
+
+		entries = append(entries, TreeEntry{Mode: mode, Name: name, Sha: fmt.Sprintf("%x", sha)})
+	}
+}
+
+// fileInfo is a minimal fs.FileInfo/fs.DirEntry backing a tree or blob.
+type fileInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+}
+
+func (fi fileInfo) Name() string               { return fi.name }
+func (fi fileInfo) Size() int64                { return fi.size }
+func (fi fileInfo) Mode() fs.FileMode          { return fi.mode }
+func (fi fileInfo) ModTime() time.Time         { return time.Time{} }
+func (fi fileInfo) IsDir() bool                { return fi.mode.IsDir() }
+func (fi fileInfo) Sys() any                   { return nil }
+func (fi fileInfo) Type() fs.FileMode          { return fi.mode.Type() }
+func (fi fileInfo) Info() (fs.FileInfo, error) { return fi, nil }
+
+func modeFor(gitMode string) fs.FileMode {
+	switch gitMode {
+	case "40000":
+		return fs.ModeDir | 0o755
+	case "120000":
+		return fs.ModeSymlink
+	case "100755":
+		return 0o755
+	default:
+		return 0o644
+	}
+}
+
+type blobFile struct {
+	info    fileInfo
+	content []byte
+	pos     int
+}
+
+func (b *blobFile) Stat() (fs.FileInfo, error) { return b.info, nil }
+func (b *blobFile) Close() error               { return nil }
+func (b *blobFile) Read(p []byte) (int, error) {
+	if b.pos >= len(b.content) {
+		return 0, io.EOF
+	}
+	n := copy(p, b.content[b.pos:])
+	b.pos += n
+	return n, nil
+}
+
+type treeDirFile struct {
+	info    fileInfo
+	entries []fs.DirEntry
+	pos     int
+}
+
+func (d *treeDirFile) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *treeDirFile) Close() error               { return nil }
+func (d *treeDirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+func (d *treeDirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		out := d.entries[d.pos:]
+		d.pos = len(d.entries)
+		return out, nil
+	}
+	if d.pos >= len(d.entries) {
+		return nil, io.EOF
+	}
+	end := d.pos + n
+	if end > len(d.entries) {
+		end = len(d.entries)
+	}
+	out := d.entries[d.pos:end]
+	d.pos = end
+	return out, nil
+}
+
+// treeFS implements fs.FS, fs.ReadDirFS, and fs.StatFS over a single tree
+// SHA, lazily resolving tree and blob objects as paths are requested.
+type treeFS struct {
+	rootSha string
+}
+
+// TreeFS returns an io/fs.FS view of the tree object named by sha, letting
+// callers fs.WalkDir or fs.ReadFile over a historical snapshot without
+// checking it out.
+func TreeFS(sha string) fs.FS {
+	return treeFS{rootSha: sha}
+}
+
+// resolve walks name from the tree root and returns the type and SHA of the
+// object it names.
+func (t treeFS) resolve(name string) (Type, string, error) {
+	if name == "." {
+		return TypeTree, t.rootSha, nil
+	}
+
+	sha := t.rootSha
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		typ, rc, err := OpenObject(sha)
+		if err != nil {
+			return "", "", err
+		}
+		if typ != TypeTree {
+			rc.Close()
+			return "", "", &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		entries, err := ParseTree(rc)
+		rc.Close()
+		if err != nil {
+			return "", "", err
+		}
+
+		found := false
+		for _, e := range entries {
+			if e.Name == part {
+				sha = e.Sha
+				found = true
+				if e.Mode == "40000" {
+					typ = TypeTree
+				} else {
+					typ = TypeBlob
+				}
+				break
+			}
+		}
+		if !found {
+			return "", "", &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		if i == len(parts)-1 {
+			return typ, sha, nil
+		}
+	}
+	return "", "", &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (t treeFS) readDir(sha string) ([]fs.DirEntry, error) {
+	typ, rc, err := OpenObject(sha)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	if typ != TypeTree {
+		return nil, fmt.Errorf("objects: %s is not a tree", sha)
+	}
+
+	entries, err := ParseTree(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]fs.DirEntry, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, fileInfo{name: e.Name, mode: modeFor(e.Mode)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out, nil
+}
+
+func (t treeFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	typ, sha, err := t.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if typ == TypeTree {
+		entries, err := t.readDir(sha)
+		if err != nil {
+			return nil, err
+		}
+		return &treeDirFile{info: fileInfo{name: name, mode: fs.ModeDir | 0o755}, entries: entries}, nil
+	}
+
+	_, rc, err := OpenObject(sha)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	return &blobFile{info: fileInfo{name: filepath.Base(name), size: int64(len(content))}, content: content}, nil
+}
+
+func (t treeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == "." {
+		return t.readDir(t.rootSha)
+	}
+	typ, sha, err := t.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if typ != TypeTree {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	return t.readDir(sha)
+}
+
+func (t treeFS) Stat(name string) (fs.FileInfo, error) {
+	if name == "." {
+		return fileInfo{name: ".", mode: fs.ModeDir | 0o755}, nil
+	}
+
+	typ, sha, err := t.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if typ == TypeTree {
+		return fileInfo{name: filepath.Base(name), mode: fs.ModeDir | 0o755}, nil
+	}
+
+	_, rc, err := OpenObject(sha)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo{name: filepath.Base(name), size: int64(len(content))}, nil
+}
@@ -0,0 +1,156 @@
+package objects
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// chdirTemp points the current process at a fresh temp directory for the
+// duration of the test, the same way tree_test.go does for package main.
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// writeTestObject hashes and zlib-compresses an object the same way the lit
+// CLI's writeObject does, persisting it under .git/objects so OpenObject and
+// TreeFS can find it by SHA.
+func writeTestObject(t *testing.T, objType string, payload []byte) string {
+	t.Helper()
+	header := fmt.Sprintf("%s %d\x00", objType, len(payload))
+	content := append([]byte(header), payload...)
+
+	hasher, err := CurrentHasher()
+	if err != nil {
+		t.Fatalf("CurrentHasher: %v", err)
+	}
+	h := hasher.New()
+	h.Write(content)
+	sha := fmt.Sprintf("%x", h.Sum(nil))
+
+	dir := filepath.Join(".git", "objects", sha[:2])
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("mkdir %s: %v", dir, err)
+	}
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(content); err != nil {
+		t.Fatalf("compressing object: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("compressing object: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, sha[2:]), buf.Bytes(), 0644); err != nil {
+		t.Fatalf("writing object: %v", err)
+	}
+	return sha
+}
+
+// writeTestTree writes a tree object from entries, mirroring writeTree's own
+// encoding: "<mode> <name>\0" followed by the entry's raw SHA bytes, sorted
+// by name.
+func writeTestTree(t *testing.T, entries []TreeEntry) string {
+	t.Helper()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	var body bytes.Buffer
+	for _, e := range entries {
+		shaBytes, err := hex.DecodeString(e.Sha)
+		if err != nil {
+			t.Fatalf("decoding sha %q: %v", e.Sha, err)
+		}
+		fmt.Fprintf(&body, "%s %s\x00", e.Mode, e.Name)
+		body.Write(shaBytes)
+	}
+	return writeTestObject(t, "tree", body.Bytes())
+}
+
+// TestTreeFSRoundTrip builds a nested tree (a root file plus a subdirectory
+// with its own file) directly against the object store, then walks and reads
+// it back through TreeFS, checking that the io/fs.FS view matches what was
+// written without ever touching the working directory.
+func TestTreeFSRoundTrip(t *testing.T) {
+	chdirTemp(t)
+
+	rootFileSha := writeTestObject(t, "blob", []byte("hello from root\n"))
+	subFileSha := writeTestObject(t, "blob", []byte("hello from sub\n"))
+
+	subTreeSha := writeTestTree(t, []TreeEntry{
+		{Mode: "100644", Name: "b.txt", Sha: subFileSha},
+	})
+	rootTreeSha := writeTestTree(t, []TreeEntry{
+		{Mode: "100644", Name: "a.txt", Sha: rootFileSha},
+		{Mode: "40000", Name: "sub", Sha: subTreeSha},
+	})
+
+	treeFS := TreeFS(rootTreeSha)
+
+	var paths []string
+	err := fs.WalkDir(treeFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != "." {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkDir: %v", err)
+	}
+	sort.Strings(paths)
+	wantPaths := []string{"a.txt", "sub", "sub/b.txt"}
+	if strings.Join(paths, ",") != strings.Join(wantPaths, ",") {
+		t.Fatalf("WalkDir paths = %v, want %v", paths, wantPaths)
+	}
+
+	rootContent, err := fs.ReadFile(treeFS, "a.txt")
+	if err != nil {
+		t.Fatalf("ReadFile(a.txt): %v", err)
+	}
+	if string(rootContent) != "hello from root\n" {
+		t.Fatalf("a.txt content = %q, want %q", rootContent, "hello from root\n")
+	}
+
+	subContent, err := fs.ReadFile(treeFS, "sub/b.txt")
+	if err != nil {
+		t.Fatalf("ReadFile(sub/b.txt): %v", err)
+	}
+	if string(subContent) != "hello from sub\n" {
+		t.Fatalf("sub/b.txt content = %q, want %q", subContent, "hello from sub\n")
+	}
+
+	info, err := fs.Stat(treeFS, "sub")
+	if err != nil {
+		t.Fatalf("Stat(sub): %v", err)
+	}
+	if !info.IsDir() {
+		t.Fatal("Stat(sub): want a directory")
+	}
+}
+
+// TestParseTreeMalformedEntry checks that a tree entry missing the space
+// between its mode and name is reported as an error rather than silently
+// misparsed.
+func TestParseTreeMalformedEntry(t *testing.T) {
+	_, err := ParseTree(strings.NewReader("100644nospaceinentry\x00"))
+	if err == nil {
+		t.Fatal("ParseTree: want error for entry missing a mode/name separator, got nil")
+	}
+}
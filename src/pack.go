@@ -0,0 +1,461 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"lit/src/objects"
+)
+
+// Git pack object type tags, as stored in the 3-bit type field of the
+// per-entry variable-length header. Type 5 is reserved by the format.
+const (
+	objCommit   = 1
+	objTree     = 2
+	objBlob     = 3
+	objTag      = 4
+	objOfsDelta = 6
+	objRefDelta = 7
+)
+
+func objTypeName(typ int) (string, error) {
+	switch typ {
+	case objCommit:
+		return "commit", nil
+	case objTree:
+		return "tree", nil
+	case objBlob:
+		return "blob", nil
+	case objTag:
+		return "tag", nil
+	default:
+		return "", fmt.Errorf("lit: unresolvable pack object type %d", typ)
+	}
+}
+
+func objTypeCode(typeName string) (int, error) {
+	switch typeName {
+	case "commit":
+		return objCommit, nil
+	case "tree":
+		return objTree, nil
+	case "blob":
+		return objBlob, nil
+	case "tag":
+		return objTag, nil
+	default:
+		return 0, fmt.Errorf("lit: unknown object type %q", typeName)
+	}
+}
+
+// countingReader wraps a bufio.Reader and tracks how many bytes have been
+// consumed from it. It implements io.ByteReader so that compress/flate reads
+// one byte at a time instead of wrapping us in its own bufio.Reader, which
+// would read past the end of the current zlib stream and corrupt our view of
+// where the next pack entry begins.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// readEntryHeader decodes the variable-length type+size header that
+// precedes every pack entry: a 3-bit type, a 4-bit low size, and then as
+// many 7-bit continuation bytes as needed for the rest of the size.
+func readEntryHeader(r io.ByteReader) (typ int, size uint64, err error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	typ = int((b >> 4) & 0x7)
+	size = uint64(b & 0x0f)
+	shift := uint(4)
+	for b&0x80 != 0 {
+		b, err = r.ReadByte()
+		if err != nil {
+			return 0, 0, err
+		}
+		size |= uint64(b&0x7f) << shift
+		shift += 7
+	}
+	return typ, size, nil
+}
+
+// readOfsDeltaOffset decodes the negative offset varint used by
+// OBJ_OFS_DELTA entries: the base object lives `offset` bytes before the
+// start of this entry's header.
+func readOfsDeltaOffset(r io.ByteReader) (int64, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	offset := int64(b & 0x7f)
+	for b&0x80 != 0 {
+		b, err = r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		offset = ((offset + 1) << 7) | int64(b&0x7f)
+	}
+	return offset, nil
+}
+
+// readDeltaVarint decodes the little-endian-ish 7-bit-per-byte varint used
+// for the source/target size fields at the start of a delta payload.
+func readDeltaVarint(r io.ByteReader) (uint64, error) {
+	var size uint64
+	var shift uint
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		size |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		shift += 7
+	}
+	return size, nil
+}
+
+// applyDelta reconstructs the target object from a base object and a delta
+// payload consisting of a COPY/ADD instruction stream, as produced by git's
+// delta encoder.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	r := bytes.NewReader(delta)
+
+	srcSize, err := readDeltaVarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading delta source size: %w", err)
+	}
+	if uint64(len(base)) != srcSize {
+		return nil, fmt.Errorf("delta base size mismatch: want %d, have %d", srcSize, len(base))
+	}
+
+	targetSize, err := readDeltaVarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading delta target size: %w", err)
+	}
+
+	out := make([]byte, 0, targetSize)
+	for r.Len() > 0 {
+		op, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		if op&0x80 != 0 {
+			var offset, size uint32
+			for i, flag := range []byte{0x01, 0x02, 0x04, 0x08} {
+				if op&flag != 0 {
+					b, err := r.ReadByte()
+					if err != nil {
+						return nil, err
+					}
+					offset |= uint32(b) << (8 * i)
+				}
+			}
+			for i, flag := range []byte{0x10, 0x20, 0x40} {
+				if op&flag != 0 {
+					b, err := r.ReadByte()
+					if err != nil {
+						return nil, err
+					}
+					size |= uint32(b) << (8 * i)
+				}
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if uint64(offset)+uint64(size) > uint64(len(base)) {
+				return nil, fmt.Errorf("delta copy out of range: offset=%d size=%d base=%d", offset, size, len(base))
+			}
+			out = append(out, base[offset:offset+size]...)
+		} else if op != 0 {
+			buf := make([]byte, op)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return nil, err
+			}
+			out = append(out, buf...)
+		} else {
+			return nil, fmt.Errorf("invalid delta opcode 0")
+		}
+	}
+
+	if uint64(len(out)) != targetSize {
+		return nil, fmt.Errorf("delta target size mismatch: want %d, got %d", targetSize, len(out))
+	}
+	return out, nil
+}
+
+func runUnpackObjects(args []string) error {
+	fs := flag.NewFlagSet("unpack-objects", flag.ExitOnError)
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: lit unpack-objects <pack-file>")
+	}
+
+	return unpackObjectsFile(fs.Arg(0))
+}
+
+type packRawEntry struct {
+	offset     int64
+	typ        int
+	data       []byte
+	baseOffset int64
+	baseSha    string
+}
+
+func unpackObjectsFile(packPath string) error {
+	f, err := os.Open(packPath)
+	if err != nil {
+		return fmt.Errorf("opening pack file: %w", err)
+	}
+	defer f.Close()
+
+	cr := &countingReader{r: bufio.NewReader(f)}
+
+	var hdr [12]byte
+	if _, err := io.ReadFull(cr, hdr[:]); err != nil {
+		return fmt.Errorf("reading pack header: %w", err)
+	}
+	if string(hdr[:4]) != "PACK" {
+		return fmt.Errorf("not a pack file (bad magic %q)", hdr[:4])
+	}
+	version := binary.BigEndian.Uint32(hdr[4:8])
+	if version != 2 && version != 3 {
+		return fmt.Errorf("unsupported pack version %d", version)
+	}
+	count := binary.BigEndian.Uint32(hdr[8:12])
+
+	hasher, err := objects.CurrentHasher()
+	if err != nil {
+		return err
+	}
+
+	entries := make([]packRawEntry, 0, count)
+	offsetIndex := make(map[int64]int, count)
+
+	for i := uint32(0); i < count; i++ {
+		entryOffset := cr.n
+		typ, size, err := readEntryHeader(cr)
+		if err != nil {
+			return fmt.Errorf("reading entry %d header: %w", i, err)
+		}
+
+		var baseOffset int64 = -1
+		var baseSha string
+		switch typ {
+		case objOfsDelta:
+			rel, err := readOfsDeltaOffset(cr)
+			if err != nil {
+				return fmt.Errorf("reading ofs-delta offset for entry %d: %w", i, err)
+			}
+			baseOffset = entryOffset - rel
+		case objRefDelta:
+			sha := make([]byte, hasher.Size())
+			if _, err := io.ReadFull(cr, sha); err != nil {
+				return fmt.Errorf("reading ref-delta base for entry %d: %w", i, err)
+			}
+			baseSha = fmt.Sprintf("%x", sha)
+		}
+
+		zr, err := zlib.NewReader(cr)
+		if err != nil {
+			return fmt.Errorf("opening zlib stream for entry %d: %w", i, err)
+		}
+		data, err := io.ReadAll(zr)
+		zr.Close()
+		if err != nil {
+			return fmt.Errorf("inflating entry %d: %w", i, err)
+		}
+		if uint64(len(data)) != size {
+			return fmt.Errorf("entry %d: expected %d bytes, got %d", i, size, len(data))
+		}
+
+		offsetIndex[entryOffset] = len(entries)
+		entries = append(entries, packRawEntry{
+			offset:     entryOffset,
+			typ:        typ,
+			data:       data,
+			baseOffset: baseOffset,
+			baseSha:    baseSha,
+		})
+	}
+
+	resolved := make([][]byte, len(entries))
+	resolvedType := make([]string, len(entries))
+	resolving := make([]bool, len(entries))
+
+	var resolve func(i int) ([]byte, string, error)
+	resolve = func(i int) ([]byte, string, error) {
+		if resolved[i] != nil {
+			return resolved[i], resolvedType[i], nil
+		}
+		if resolving[i] {
+			return nil, "", fmt.Errorf("entry %d: cyclic delta chain", i)
+		}
+		resolving[i] = true
+		defer func() { resolving[i] = false }()
+
+		e := entries[i]
+		switch e.typ {
+		case objOfsDelta, objRefDelta:
+			var base []byte
+			var baseType string
+			var err error
+			switch e.typ {
+			case objOfsDelta:
+				baseIdx, ok := offsetIndex[e.baseOffset]
+				if !ok {
+					return nil, "", fmt.Errorf("entry %d: no pack entry at base offset %d", i, e.baseOffset)
+				}
+				base, baseType, err = resolve(baseIdx)
+			default: // objRefDelta: base is assumed to already be a loose object
+				baseType, base, err = openObject(e.baseSha)
+			}
+			if err != nil {
+				return nil, "", fmt.Errorf("resolving base for entry %d: %w", i, err)
+			}
+			out, err := applyDelta(base, e.data)
+			if err != nil {
+				return nil, "", fmt.Errorf("applying delta for entry %d: %w", i, err)
+			}
+			resolved[i] = out
+			resolvedType[i] = baseType
+			return out, baseType, nil
+		default:
+			typeName, err := objTypeName(e.typ)
+			if err != nil {
+				return nil, "", err
+			}
+			resolved[i] = e.data
+			resolvedType[i] = typeName
+			return e.data, typeName, nil
+		}
+	}
+
+	for i := range entries {
+		data, typeName, err := resolve(i)
+		if err != nil {
+			return fmt.Errorf("resolving entry %d: %w", i, err)
+		}
+		if _, err := writeObject(typeName, data, true); err != nil {
+			return fmt.Errorf("writing entry %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// runPackObjects implements a minimal `lit pack-objects <base-name>`: it
+// reads newline-separated object SHAs from stdin (as produced by a rev-list
+// walk) and writes an undeltified pack containing each one to
+// <base-name>.pack.
+func runPackObjects(args []string) error {
+	fs := flag.NewFlagSet("pack-objects", flag.ExitOnError)
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: lit pack-objects <base-name> (reads object SHAs on stdin)")
+	}
+
+	return packObjectsTo(fs.Arg(0)+".pack", os.Stdin)
+}
+
+func packObjectsTo(packPath string, shaList io.Reader) error {
+	var shas []string
+	scanner := bufio.NewScanner(shaList)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		shas = append(shas, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading object list: %w", err)
+	}
+
+	f, err := os.Create(packPath)
+	if err != nil {
+		return fmt.Errorf("creating pack file: %w", err)
+	}
+	defer f.Close()
+
+	hasher, err := objects.CurrentHasher()
+	if err != nil {
+		return err
+	}
+	h := hasher.New()
+	w := io.MultiWriter(f, h)
+
+	var hdr [12]byte
+	copy(hdr[:4], "PACK")
+	binary.BigEndian.PutUint32(hdr[4:8], 2)
+	binary.BigEndian.PutUint32(hdr[8:12], uint32(len(shas)))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+
+	for _, sha := range shas {
+		typeName, body, err := openObject(sha)
+		if err != nil {
+			return fmt.Errorf("reading object %s: %w", sha, err)
+		}
+		typ, err := objTypeCode(typeName)
+		if err != nil {
+			return err
+		}
+		if err := writeEntryHeader(w, typ, uint64(len(body))); err != nil {
+			return err
+		}
+		zw := zlib.NewWriter(w)
+		if _, err := zw.Write(body); err != nil {
+			zw.Close()
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := f.Write(h.Sum(nil)); err != nil {
+		return fmt.Errorf("writing pack checksum: %w", err)
+	}
+	return nil
+}
+
+func writeEntryHeader(w io.Writer, typ int, size uint64) error {
+	b := byte(typ&0x7)<<4 | byte(size&0x0f)
+	size >>= 4
+	for size > 0 {
+		if _, err := w.Write([]byte{b | 0x80}); err != nil {
+			return err
+		}
+		b = byte(size & 0x7f)
+		size >>= 7
+	}
+	_, err := w.Write([]byte{b})
+	return err
+}
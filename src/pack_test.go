@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// deltaVarint encodes n using the 7-bit-per-byte scheme readDeltaVarint
+// expects, for use in building test delta payloads.
+func deltaVarint(n uint64) []byte {
+	var out []byte
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n != 0 {
+			out = append(out, b|0x80)
+		} else {
+			out = append(out, b)
+			return out
+		}
+	}
+}
+
+func TestApplyDeltaCopyAndAdd(t *testing.T) {
+	base := []byte("hello world")
+
+	var delta bytes.Buffer
+	delta.Write(deltaVarint(uint64(len(base)))) // source size
+	delta.Write(deltaVarint(11))                // target size: "world there"
+
+	// COPY offset=6 size=5 ("world")
+	delta.WriteByte(0x80 | 0x01 | 0x10)
+	delta.WriteByte(6)
+	delta.WriteByte(5)
+
+	// ADD " there"
+	add := []byte(" there")
+	delta.WriteByte(byte(len(add)))
+	delta.Write(add)
+
+	got, err := applyDelta(base, delta.Bytes())
+	if err != nil {
+		t.Fatalf("applyDelta: %v", err)
+	}
+	if want := "world there"; string(got) != want {
+		t.Fatalf("applyDelta = %q, want %q", got, want)
+	}
+}
+
+func TestApplyDeltaBaseSizeMismatch(t *testing.T) {
+	base := []byte("hello")
+
+	var delta bytes.Buffer
+	delta.Write(deltaVarint(99)) // wrong source size
+	delta.Write(deltaVarint(0))
+
+	if _, err := applyDelta(base, delta.Bytes()); err == nil {
+		t.Fatal("applyDelta: want error on base size mismatch, got nil")
+	}
+}
+
+func TestApplyDeltaCopyOutOfRange(t *testing.T) {
+	base := []byte("hi")
+
+	var delta bytes.Buffer
+	delta.Write(deltaVarint(uint64(len(base))))
+	delta.Write(deltaVarint(5))
+
+	// COPY offset=0 size=5, but base is only 2 bytes long
+	delta.WriteByte(0x80 | 0x01 | 0x10)
+	delta.WriteByte(0)
+	delta.WriteByte(5)
+
+	if _, err := applyDelta(base, delta.Bytes()); err == nil {
+		t.Fatal("applyDelta: want error on out-of-range copy, got nil")
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+func runWriteTree(args []string) error {
+	sha, err := writeTree(".")
+	if err != nil {
+		return err
+	}
+	fmt.Println(sha)
+	return nil
+}
+
+type treeEntryToWrite struct {
+	mode string
+	name string
+	sha  string
+}
+
+// writeTree recursively builds and writes a tree object for dir, skipping
+// .git, and returns its hex object ID in the repo's configured hash format.
+func writeTree(dir string) (string, error) {
+	items, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("reading directory %s: %w", dir, err)
+	}
+
+	entries := make([]treeEntryToWrite, 0, len(items))
+	for _, item := range items {
+		if item.Name() == ".git" {
+			continue
+		}
+		path := filepath.Join(dir, item.Name())
+
+		if item.IsDir() {
+			sha, err := writeTree(path)
+			if err != nil {
+				return "", err
+			}
+			entries = append(entries, treeEntryToWrite{mode: "40000", name: item.Name(), sha: sha})
+			continue
+		}
+
+		info, err := item.Info()
+		if err != nil {
+			return "", fmt.Errorf("stat %s: %w", path, err)
+		}
+
+		mode := "100644"
+		var content []byte
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			mode = "120000"
+			target, err := os.Readlink(path)
+			if err != nil {
+				return "", fmt.Errorf("reading link %s: %w", path, err)
+			}
+			content = []byte(target)
+		case info.Mode()&0111 != 0:
+			mode = "100755"
+			content, err = os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("reading %s: %w", path, err)
+			}
+		default:
+			content, err = os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("reading %s: %w", path, err)
+			}
+		}
+
+		sha, err := writeObject("blob", content, true)
+		if err != nil {
+			return "", fmt.Errorf("writing blob for %s: %w", path, err)
+		}
+		entries = append(entries, treeEntryToWrite{mode: mode, name: item.Name(), sha: sha})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	var body bytes.Buffer
+	for _, e := range entries {
+		shaBytes, err := hex.DecodeString(e.sha)
+		if err != nil {
+			return "", fmt.Errorf("invalid sha %s: %w", e.sha, err)
+		}
+		fmt.Fprintf(&body, "%s %s\x00", e.mode, e.name)
+		body.Write(shaBytes)
+	}
+
+	return writeObject("tree", body.Bytes(), true)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"testing"
+)
+
+func TestWriteTreeSymlinkStoresLinkText(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	if err := os.WriteFile("target.txt", []byte("this is the target file's content\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("target.txt", "link.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	treeSha, err := writeTree(".")
+	if err != nil {
+		t.Fatalf("writeTree: %v", err)
+	}
+
+	_, body, err := openObject(treeSha)
+	if err != nil {
+		t.Fatalf("openObject(tree): %v", err)
+	}
+
+	shas := make(map[string]string) // name -> hex sha
+	for len(body) > 0 {
+		sp := bytes.IndexByte(body, ' ')
+		nul := bytes.IndexByte(body, 0)
+		name := string(body[sp+1 : nul])
+		sha := body[nul+1 : nul+1+20]
+		shas[name] = hex.EncodeToString(sha)
+		body = body[nul+1+20:]
+	}
+
+	linkSha, targetSha := shas["link.txt"], shas["target.txt"]
+	if linkSha == "" || targetSha == "" {
+		t.Fatalf("missing entries in tree: %+v", shas)
+	}
+	if linkSha == targetSha {
+		t.Fatal("link.txt blob matches target.txt blob: symlink was followed instead of storing its link text")
+	}
+
+	_, linkBody, err := openObject(linkSha)
+	if err != nil {
+		t.Fatalf("openObject(link): %v", err)
+	}
+	if string(linkBody) != "target.txt" {
+		t.Fatalf("link.txt blob = %q, want %q", linkBody, "target.txt")
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"lit/src/objects"
+)
+
+// defaultVerifyIgnore lists directory names verify-tree skips when walking,
+// since they hold build output or vendored code rather than tracked content.
+var defaultVerifyIgnore = map[string]bool{".git": true, "vendor": true}
+
+// verifyTreeFlags builds the "verify-tree" FlagSet, shared between
+// runVerifyTree and the Command registry's help text.
+func verifyTreeFlags(mode flag.ErrorHandling) (fs *flag.FlagSet, against *string, ignore *string) {
+	fs = flag.NewFlagSet("verify-tree", mode)
+	against = fs.String("against", "", "tree SHA to verify the directory against")
+	ignore = fs.String("ignore", "", "comma-separated names to skip in addition to the defaults (.git, vendor)")
+	return fs, against, ignore
+}
+
+// verifyIgnoreSet builds the ignore set for a run: defaultVerifyIgnore plus
+// whatever extra comma-separated names were passed via --ignore.
+func verifyIgnoreSet(extra string) map[string]bool {
+	ignore := make(map[string]bool, len(defaultVerifyIgnore))
+	for name := range defaultVerifyIgnore {
+		ignore[name] = true
+	}
+	for _, name := range strings.Split(extra, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			ignore[name] = true
+		}
+	}
+	return ignore
+}
+
+// runVerifyTree implements `lit verify-tree [--against <tree_sha>] [--ignore
+// <names>] [dir]`: it computes a deterministic SHA-256 digest of an on-disk
+// directory, independent of the Git object store, so drift between a
+// checkout and a recorded tree can be detected without relying on Git's own
+// SHA scheme. The ignore set defaults to .git and vendor and is extended by
+// --ignore.
+func runVerifyTree(args []string) error {
+	flags, against, ignoreFlag := verifyTreeFlags(flag.ExitOnError)
+	flags.Parse(args)
+
+	dir := "."
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	}
+
+	ignore := verifyIgnoreSet(*ignoreFlag)
+
+	digest, err := hashDirectory(diskFS{FS: os.DirFS(dir), root: dir}, ".", ignore)
+	if err != nil {
+		return err
+	}
+
+	if *against == "" {
+		fmt.Println(digest)
+		return nil
+	}
+
+	wantDigest, err := hashDirectory(objects.TreeFS(*against), ".", ignore)
+	if err != nil {
+		return err
+	}
+
+	if digest != wantDigest {
+		return fmt.Errorf("mismatch: %s has %s, tree %s has %s", dir, digest, *against, wantDigest)
+	}
+	fmt.Printf("match: %s\n", digest)
+	return nil
+}
+
+// diskFS wraps os.DirFS so hashDirectory can tell a symlink entry apart from
+// the file it resolves to. os.DirFS's own Open follows symlinks, which would
+// hash the target's content instead of the link text writeTree actually
+// stores for a 120000 tree entry.
+type diskFS struct {
+	fs.FS
+	root string
+}
+
+func (d diskFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(d.FS, name)
+}
+
+func (d diskFS) ReadLink(name string) (string, error) {
+	return os.Readlink(filepath.Join(d.root, name))
+}
+
+// hashDirectory recursively digests name within fsys: "file\x00<relpath>\x00"
+// followed by the hex SHA-256 of the file's contents and "\x00" for each
+// regular file, or "dir\x00<relpath>\x00" followed by the subdirectory's own
+// digest and "\x00" for each directory, all lexically ordered and fed into
+// one top-level SHA-256.
+func hashDirectory(fsys fs.FS, name string, ignore map[string]bool) (string, error) {
+	entries, err := fs.ReadDir(fsys, name)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", name, err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	h := sha256.New()
+	for _, entry := range entries {
+		if ignore[entry.Name()] {
+			continue
+		}
+
+		childPath := entry.Name()
+		if name != "." {
+			childPath = name + "/" + entry.Name()
+		}
+
+		if entry.IsDir() {
+			fmt.Fprintf(h, "dir\x00%s\x00", childPath)
+			childDigest, err := hashDirectory(fsys, childPath, ignore)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(h, "%s\x00", childDigest)
+			continue
+		}
+
+		fmt.Fprintf(h, "file\x00%s\x00", childPath)
+		fileDigest, err := hashFile(fsys, childPath, entry.Type()&fs.ModeSymlink != 0)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00", fileDigest)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// hashFile hashes path's content within fsys. For a symlink entry it hashes
+// the link's target text rather than the file it resolves to, matching what
+// writeTree stores for a 120000 tree entry: on fsys that can tell symlinks
+// apart (diskFS) it reads the target via ReadLink; on fsys where a symlink's
+// "content" already is its target text (objects.TreeFS's blobs), a plain
+// Open does the right thing without any special-casing.
+func hashFile(fsys fs.FS, path string, isSymlink bool) (string, error) {
+	if isSymlink {
+		if rl, ok := fsys.(interface{ ReadLink(string) (string, error) }); ok {
+			target, err := rl.ReadLink(path)
+			if err != nil {
+				return "", fmt.Errorf("reading symlink %s: %w", path, err)
+			}
+			h := sha256.New()
+			io.WriteString(h, target)
+			return fmt.Sprintf("%x", h.Sum(nil)), nil
+		}
+	}
+
+	f, err := fsys.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hashing %s: %w", path, err)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// chdirTemp points the current process at a fresh temp directory for the
+// duration of the test, restoring the original working directory afterward.
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestHashDirectoryDeterministic(t *testing.T) {
+	chdirTemp(t)
+
+	if err := os.Mkdir("sub", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("a.txt", []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("sub/b.txt", []byte("world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := diskFS{FS: os.DirFS("."), root: "."}
+
+	first, err := hashDirectory(fsys, ".", defaultVerifyIgnore)
+	if err != nil {
+		t.Fatalf("hashDirectory: %v", err)
+	}
+	second, err := hashDirectory(fsys, ".", defaultVerifyIgnore)
+	if err != nil {
+		t.Fatalf("hashDirectory: %v", err)
+	}
+	if first != second {
+		t.Fatalf("hashDirectory not deterministic: %s != %s", first, second)
+	}
+	if first == "" {
+		t.Fatal("hashDirectory returned empty digest")
+	}
+}
+
+func TestVerifyIgnoreSetExcludesName(t *testing.T) {
+	chdirTemp(t)
+
+	if err := os.WriteFile("a.txt", []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir("ignored", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("ignored/junk.txt", []byte("junk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := diskFS{FS: os.DirFS("."), root: "."}
+
+	withoutIgnored, err := hashDirectory(fsys, ".", verifyIgnoreSet("ignored"))
+	if err != nil {
+		t.Fatalf("hashDirectory: %v", err)
+	}
+
+	if err := os.RemoveAll("ignored"); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := hashDirectory(fsys, ".", defaultVerifyIgnore)
+	if err != nil {
+		t.Fatalf("hashDirectory: %v", err)
+	}
+
+	if withoutIgnored != baseline {
+		t.Fatalf("--ignore did not exclude %q: %s != %s", "ignored", withoutIgnored, baseline)
+	}
+}
+
+func TestVerifyIgnoreSetKeepsDefaults(t *testing.T) {
+	ignore := verifyIgnoreSet("vendor2")
+	for _, name := range []string{".git", "vendor", "vendor2"} {
+		if !ignore[name] {
+			t.Fatalf("verifyIgnoreSet(%q) missing %q", "vendor2", name)
+		}
+	}
+}
+
+func TestHashFileSymlinkDiffersFromTargetContent(t *testing.T) {
+	chdirTemp(t)
+
+	if err := os.WriteFile("target.txt", []byte("target content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("target.txt", "link.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := diskFS{FS: os.DirFS("."), root: "."}
+
+	symDigest, err := hashFile(fsys, "link.txt", true)
+	if err != nil {
+		t.Fatalf("hashFile(symlink): %v", err)
+	}
+	followedDigest, err := hashFile(fsys, "link.txt", false)
+	if err != nil {
+		t.Fatalf("hashFile(followed): %v", err)
+	}
+	if symDigest == followedDigest {
+		t.Fatal("symlink digest matches dereferenced target content digest: link text was not hashed literally")
+	}
+
+	targetDigest, err := hashFile(fsys, "target.txt", false)
+	if err != nil {
+		t.Fatalf("hashFile(target.txt): %v", err)
+	}
+	if symDigest == targetDigest {
+		t.Fatal("symlink digest matches target file's own content digest: link text was not hashed literally")
+	}
+}
+
+func TestRunVerifyTreeAgainstMatchAndMismatch(t *testing.T) {
+	chdirTemp(t)
+
+	if err := runInit(nil); err != nil {
+		t.Fatalf("runInit: %v", err)
+	}
+	if err := os.WriteFile("a.txt", []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	treeSha, err := writeTree(".")
+	if err != nil {
+		t.Fatalf("writeTree: %v", err)
+	}
+
+	if err := runVerifyTree([]string{"--against", treeSha}); err != nil {
+		t.Fatalf("runVerifyTree(--against matching tree): unexpected error: %v", err)
+	}
+
+	if err := os.WriteFile("a.txt", []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = runVerifyTree([]string{"--against", treeSha})
+	if err == nil {
+		t.Fatal("runVerifyTree(--against stale tree): want mismatch error, got nil")
+	}
+	if !strings.Contains(err.Error(), "mismatch") {
+		t.Fatalf("runVerifyTree error = %q, want it to mention mismatch", err)
+	}
+}